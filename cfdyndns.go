@@ -9,6 +9,11 @@
 //   - Scheduled automatic updates using cron jobs (Auto method)
 //   - Support for both proxied and unproxied DNS records
 //   - Handles subdomains and zone apex updates
+//   - Declarative management of many zones/records at once via a YAML config file (NewFromConfig, RunAll)
+//   - Typed helpers for CNAME, TXT, MX and SRV records (SetCNAME, SetTXT, SetMX, SetSRV) alongside A/AAAA
+//   - OnChange/OnError hooks for observing updates and failures, with adapters in the notify subpackage
+//   - Bounded retries with exponential backoff around Cloudflare and IP-detection calls (RetryPolicy)
+//   - Optional Prometheus metrics and /healthz, /readyz endpoints (WithMetrics, ServeMetrics, metrics subpackage)
 //
 // This package is particularly useful for maintaining up-to-date DNS records for machines
 // with dynamic IP addresses, effectively turning a Cloudflare-managed domain into a dynamic DNS service.
@@ -19,15 +24,17 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"net"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	l "github.com/charmbracelet/log"
 	"github.com/cloudflare/cloudflare-go"
-	"github.com/jipaix/whatsmyip"
 	cr "github.com/robfig/cron/v3"
+
+	"github.com/JiPaix/cfdyndns/metrics"
 )
 
 var log = setupLogger()
@@ -35,23 +42,50 @@ var log = setupLogger()
 // cfdyndns represents the main structure for interacting with the Cloudflare DNS API.
 type cfdyndns struct {
 	api  *cloudflare.API
+	cron *cr.Cron
+	cfg  *ConfigModel
+
+	// ipMu guards ip/v4/ip4/ip6 below: refreshIP writes them from the cron goroutine on
+	// every tick, while Set/SetDual/SetA/SetAAAA read them from whatever goroutine calls
+	// them (including a concurrent cron tick for a different record).
+	ipMu sync.Mutex
 	ip   string
 	v4   bool
-	cron *cr.Cron
+	ip4  string
+	ip6  string
+
+	ipSource IPSource
+	onChange ChangeFunc
+	onError  ErrorFunc
+	retry    RetryPolicy
+	metrics  *metrics.Registry
 }
 
 // New creates a new instance of cfdyndns with the provided Cloudflare API token.
 //
-// It initializes the Cloudflare API client, detects the current IP address,
-// and sets up a cron scheduler for automatic updates.
+// It initializes the Cloudflare API client, detects the current public IPv4 and IPv6
+// addresses, and sets up a cron scheduler for automatic updates. IP detection is
+// performed by an IPSource, defaulting to the jipaix/whatsmyip HTTP echo service; pass
+// WithIPSource to use a different backend. IPSource.Detect takes a wantV4 flag, so New
+// queries it once per family instead of hoping a single lookup happens to return both; a
+// source that genuinely knows how to resolve both (HTTPIPSource with URLsV6 set,
+// DNSIPSource, InterfaceIPSource) is required to make SetDual useful. The default
+// whatsmyipSource has no notion of family and will only ever satisfy whichever one the
+// underlying service happened to answer with.
+//
+// Detection of the two address families is best-effort: a machine/source that only has
+// one of the two (e.g. no IPv6 connectivity) still succeeds as long as at least one
+// address is found. ip/v4 keep pointing at whichever family was detected first,
+// preserving the behavior of single-stack callers.
 //
 // Parameters:
 //   - token: A string containing the Cloudflare API token.
+//   - opts: Optional functional options, e.g. WithIPSource.
 //
 // Returns:
 //   - *cfdyndns: A pointer to the newly created cfdyndns instance.
 //   - error: An error if any issues occur during initialization.
-func New(token string) (*cfdyndns, error) {
+func New(token string, opts ...Option) (*cfdyndns, error) {
 	api, err := cloudflare.NewWithAPIToken(token)
 
 	if err != nil {
@@ -59,27 +93,114 @@ func New(token string) (*cfdyndns, error) {
 		return nil, err
 	}
 
-	ip, _, err := whatsmyip.Get()
-	if err != nil {
-		return nil, err
+	ctx := &cfdyndns{api: api, cron: cr.New(), ipSource: whatsmyipSource{}, retry: DefaultRetryPolicy()}
+	for _, opt := range opts {
+		opt(ctx)
 	}
 
-	ipnet := net.ParseIP(ip)
-	if ipnet == nil {
-		log.Error("Error parsing IP address", "ip", ip)
-		return nil, errors.New("could not parse IP")
+	var ip4, ip6 string
+	var err4, err6 error
+
+	err4 = ctx.withRetry(context.Background(), "detect IPv4", func() error {
+		var e error
+		ip4, e = detectIP(context.Background(), ctx.ipSource, true)
+		return e
+	})
+	if err4 != nil {
+		log.Warn("Could not detect IPv4 address", "error", err4)
+	} else {
+		log.Infof("IP (v4): %s", ip4)
 	}
 
-	v4 := ipnet.To4() != nil
-	if v4 {
-		log.Infof("IP (v4): %s", ip)
+	err6 = ctx.withRetry(context.Background(), "detect IPv6", func() error {
+		var e error
+		ip6, e = detectIP(context.Background(), ctx.ipSource, false)
+		return e
+	})
+	if err6 != nil {
+		log.Warn("Could not detect IPv6 address", "error", err6)
 	} else {
-		log.Info("IP (v6): %s", ip)
+		log.Info("IP (v6): %s", ip6)
+	}
+
+	if ip4 == "" && ip6 == "" {
+		return nil, errors.New("could not detect a public IPv4 or IPv6 address")
+	}
+
+	ctx.ip4 = ip4
+	ctx.ip6 = ip6
+	ctx.ip = ip4
+	ctx.v4 = true
+	if ctx.ip == "" {
+		ctx.ip = ip6
+		ctx.v4 = false
+	}
+
+	return ctx, nil
+}
+
+// detectIP resolves the machine's current public IP address for the requested family
+// using src.
+//
+// Parameters:
+//   - ctx: Controls cancellation/timeout of the underlying lookup.
+//   - src: The IPSource to query.
+//   - wantV4: true to resolve an IPv4 address, false for IPv6.
+//
+// Returns:
+//   - string: The detected IP address, or "" if the family is unavailable.
+//   - error: An error if detection failed or returned an address of the wrong family.
+func detectIP(ctx context.Context, src IPSource, wantV4 bool) (string, error) {
+	ip, err := src.Detect(ctx, wantV4)
+	if err != nil {
+		return "", err
+	}
+
+	if (ip.To4() != nil) != wantV4 {
+		return "", fmt.Errorf("IPSource returned an address of the wrong family for the request")
+	}
+
+	return ip.String(), nil
+}
+
+// refreshIP re-detects the public IP for the family identified by wantV4 and compares it
+// against that family's own cache (ctx.ip4 for wantV4, ctx.ip6 otherwise), reporting
+// changed only if that family's address actually moved. Each scheduled record refreshes
+// its own family independently, so an AAAA record's tick can never be short-circuited by
+// an unrelated A record's IP being unchanged, or vice versa.
+func (ctx *cfdyndns) refreshIP(wantV4 bool) (changed bool, ip string, err error) {
+	var newIP string
+
+	err = ctx.withRetry(context.Background(), "refresh IP", func() error {
+		ipnet, e := ctx.ipSource.Detect(context.Background(), wantV4)
+		if e != nil {
+			return e
+		}
+		newIP = ipnet.String()
+		return nil
+	})
+	if err != nil {
+		return false, "", err
 	}
 
-	cron := cr.New()
+	ctx.ipMu.Lock()
+	defer ctx.ipMu.Unlock()
 
-	return &cfdyndns{api, ip, v4, cron}, nil
+	cached := &ctx.ip6
+	if wantV4 {
+		cached = &ctx.ip4
+	}
+
+	if newIP == *cached {
+		return false, *cached, nil
+	}
+
+	*cached = newIP
+	if wantV4 == ctx.v4 {
+		ctx.ip = newIP
+	}
+
+	return true, newIP, nil
 }
 
 // Set updates or creates a DNS record for the specified domain and subdomain.
@@ -92,64 +213,188 @@ func New(token string) (*cfdyndns, error) {
 // Returns:
 //   - error: An error if any issues occur during the update process.
 func (ctx *cfdyndns) Set(domain string, subdomain string, proxied bool) error {
+	ctx.ipMu.Lock()
+	ip, v4 := ctx.ip, ctx.v4
+	ctx.ipMu.Unlock()
+
+	recordType := "A"
+	if !v4 {
+		recordType = "AAAA"
+	}
+	return ctx.setRecord(domain, subdomain, recordSpec{Type: recordType, Content: ip}, proxied)
+}
+
+// SetDual upserts both an A and an AAAA record for the specified domain and subdomain,
+// pointing them at the machine's cached IPv4 and IPv6 addresses respectively.
+//
+// Either family is skipped if it wasn't detected by New (e.g. the machine has no IPv6
+// connectivity). If both are available, a failure on one record does not prevent the
+// other from being applied; their errors are joined so callers can inspect partial
+// success.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain to update or create. Use "@" or an empty string for the zone apex.
+//   - proxied: A boolean indicating whether the records should be proxied through Cloudflare.
+//
+// Returns:
+//   - error: An error joining any issues occurred while updating the A and/or AAAA records. nil if both succeeded.
+func (ctx *cfdyndns) SetDual(domain string, subdomain string, proxied bool) error {
+	ctx.ipMu.Lock()
+	ip4, ip6 := ctx.ip4, ctx.ip6
+	ctx.ipMu.Unlock()
+
+	if ip4 == "" && ip6 == "" {
+		return errors.New("no IPv4 or IPv6 address available for dual-stack update")
+	}
+
+	var errA, errAAAA error
+	if ip4 != "" {
+		errA = ctx.setRecord(domain, subdomain, recordSpec{Type: "A", Content: ip4}, proxied)
+	} else {
+		log.Warn("Skipping A record, no IPv4 address detected", "record", subdomain)
+	}
+
+	if ip6 != "" {
+		errAAAA = ctx.setRecord(domain, subdomain, recordSpec{Type: "AAAA", Content: ip6}, proxied)
+	} else {
+		log.Warn("Skipping AAAA record, no IPv6 address detected", "record", subdomain)
+	}
+
+	return errors.Join(errA, errAAAA)
+}
+
+// setRecord upserts a single DNS record described by spec.
+func (ctx *cfdyndns) setRecord(domain string, subdomain string, spec recordSpec, proxied bool) error {
+	zone := domain
 	domain, subdomain = fixZoneAndRecord(domain, subdomain)
 
 	// Get the zone ID for the domain
-	id, err := ctx.api.ZoneIDByName(domain)
+	var id string
+	err := ctx.withRetry(context.Background(), "ZoneIDByName", func() error {
+		var e error
+		id, e = ctx.api.ZoneIDByName(domain)
+		return e
+	})
 	if err != nil {
+		ctx.notifyError(err, "cloudflare")
 		return err
 	}
 
 	log.Infof("Found domain %s", domain)
 
-	records, _, err := ctx.api.ListDNSRecords(context.Background(), cloudflare.ZoneIdentifier(id), cloudflare.ListDNSRecordsParams{
-		Name: subdomain,
+	var records []cloudflare.DNSRecord
+	err = ctx.withRetry(context.Background(), "ListDNSRecords", func() error {
+		var e error
+		records, _, e = ctx.api.ListDNSRecords(context.Background(), cloudflare.ZoneIdentifier(id), cloudflare.ListDNSRecordsParams{
+			Name: subdomain,
+			Type: spec.Type,
+		})
+		return e
 	})
 	if err != nil {
 		log.Error("Error listing DNS records", "error", err)
+		ctx.notifyError(err, "cloudflare")
 		return err
 	}
 
+	ttl := spec.TTL
+	if ttl == 0 {
+		ttl = 1 // Cloudflare's "automatic" TTL
+	}
+
 	toAdd := cloudflare.CreateDNSRecordParams{
-		Type: (func() string {
-			if ctx.v4 {
-				return "A"
-			}
-			return "AAAA"
-		})(),
-		Name:    subdomain,
-		Content: ctx.ip,
-		Proxied: &proxied,
+		Type:     spec.Type,
+		Name:     subdomain,
+		Content:  spec.Content,
+		TTL:      ttl,
+		Priority: spec.Priority,
+		Data:     spec.Data,
+		Proxied:  &proxied,
+	}
+
+	var oldContent string
+	if len(records) > 0 {
+		oldContent = records[0].Content
 	}
 
 	var r cloudflare.DNSRecord
 	if len(records) == 0 {
 		log.Infof("Adding %s", subdomain)
-		r, err = ctx.api.CreateDNSRecord(context.Background(), cloudflare.ZoneIdentifier(id), toAdd)
+		err = ctx.withRetry(context.Background(), "CreateDNSRecord", func() error {
+			var e error
+			r, e = ctx.api.CreateDNSRecord(context.Background(), cloudflare.ZoneIdentifier(id), toAdd)
+			return e
+		})
 		if err != nil {
+			ctx.notifyError(err, "cloudflare")
 			return err
 		}
+		ctx.notifyChange(zone, subdomain, spec.Type, oldContent, spec.Content, proxied)
+		return nil
+	}
+
+	existing := records[0]
+	if recordUpToDate(existing, spec, ttl, proxied) {
+		log.Info("No change", "record", subdomain, "type", spec.Type, "content", spec.Content, "proxied", proxied)
+		ctx.notifySynced(subdomain)
 		return nil
 	}
 
 	log.Infof("Updating %s", subdomain)
-	r, err = ctx.api.UpdateDNSRecord(context.Background(), cloudflare.ZoneIdentifier(id), cloudflare.UpdateDNSRecordParams{
-		ID:      records[0].ID,
-		Name:    toAdd.Name,
-		Type:    toAdd.Type,
-		Content: toAdd.Content,
-		Proxied: &proxied,
+	err = ctx.withRetry(context.Background(), "UpdateDNSRecord", func() error {
+		var e error
+		r, e = ctx.api.UpdateDNSRecord(context.Background(), cloudflare.ZoneIdentifier(id), cloudflare.UpdateDNSRecordParams{
+			ID:       records[0].ID,
+			Name:     toAdd.Name,
+			Type:     toAdd.Type,
+			Content:  toAdd.Content,
+			TTL:      ttl,
+			Priority: spec.Priority,
+			Data:     spec.Data,
+			Proxied:  &proxied,
+		})
+		return e
 	})
 
 	if err != nil {
 		log.Error("Error updating DNS record", "error", err)
+		ctx.notifyError(err, "cloudflare")
 		return err
 	}
 
-	log.Info("Done", "record", subdomain, "type", r.Type, "ip", ctx.ip, "proxied", proxied)
+	log.Info("Done", "record", subdomain, "type", r.Type, "content", spec.Content, "proxied", proxied)
+	ctx.notifyChange(zone, subdomain, spec.Type, oldContent, spec.Content, proxied)
 	return nil
 }
 
+// recordUpToDate reports whether existing already matches spec/ttl/proxied closely enough
+// that setRecord can skip the update call. Priority and Data are compared alongside the
+// fields every record type has, since MX (Priority) and SRV (Data) changes would
+// otherwise look identical to cloudflare-go's Content/TTL/Proxied fields and get skipped.
+func recordUpToDate(existing cloudflare.DNSRecord, spec recordSpec, ttl int, proxied bool) bool {
+	if existing.Type != spec.Type || existing.Content != spec.Content || existing.TTL != ttl {
+		return false
+	}
+	if existing.Proxied == nil || *existing.Proxied != proxied {
+		return false
+	}
+	if !priorityEqual(existing.Priority, spec.Priority) {
+		return false
+	}
+	if !reflect.DeepEqual(existing.Data, spec.Data) {
+		return false
+	}
+	return true
+}
+
+func priorityEqual(a, b *uint16) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 // Auto sets up automatic updating of a DNS record on a specified cron schedule.
 //
 // It immediately sets the DNS record and then schedules future updates based on the provided cron expression.
@@ -164,25 +409,67 @@ func (ctx *cfdyndns) Set(domain string, subdomain string, proxied bool) error {
 //   - stop: A function that can be called to stop the automatic updates.
 //   - error: An error if any issues occur during setup or the initial update.
 func (ctx *cfdyndns) Auto(domain string, subdomain string, proxied bool, cron string) (stop func(), err error) {
-	domain, subdomain = fixZoneAndRecord(domain, subdomain)
+	ctx.ipMu.Lock()
+	wantV4 := ctx.v4
+	ctx.ipMu.Unlock()
 
-	err = ctx.Set(domain, subdomain, proxied)
-	if err != nil {
+	return ctx.schedule(domain, subdomain, cron, &wantV4, func() error {
+		return ctx.Set(domain, subdomain, proxied)
+	})
+}
+
+// schedule registers apply to run immediately and then again on every cron tick, wiring
+// up metrics registration and cron-level logging shared by Auto and RunAll. schedule runs
+// the raw domain and subdomain through fixZoneAndRecord to get the record key for metrics
+// registration and cron logging; apply's own setRecord call fixes the same raw values
+// itself, so both agree on the key only as long as apply uses the raw domain/subdomain it
+// closed over rather than an already-fixed one.
+//
+// ipFamily controls whether a cron tick re-detects the public IP first and skips apply
+// when that family's address hasn't moved: nil for record types whose content doesn't
+// track the machine's IP (e.g. CNAME), true for an A record, false for AAAA. Each
+// scheduled record refreshes only its own family's cached address (ctx.ip4 or ctx.ip6),
+// so RunAll can schedule many A and AAAA records — each on its own cron expression —
+// without their ticks comparing against or clobbering one another's cached IP.
+func (ctx *cfdyndns) schedule(domain string, subdomain string, cron string, ipFamily *bool, apply func() error) (stop func(), err error) {
+	_, fixedRecord := fixZoneAndRecord(domain, subdomain)
+
+	if ctx.metrics != nil {
+		ctx.metrics.RegisterRecord(fixedRecord)
+	}
+
+	if err := apply(); err != nil {
 		return nil, err
 	}
 
 	entryID, err := ctx.cron.AddFunc(cron, func() {
-		ctx.Set(domain, subdomain, proxied)
+		if ipFamily != nil {
+			changed, ip, err := ctx.refreshIP(*ipFamily)
+			if err != nil {
+				log.Error("Error refreshing IP", "error", err, "record", fixedRecord)
+				ctx.notifyError(err, "ip-detection")
+				return
+			}
+			if !changed {
+				log.Debug("IP unchanged since last run, skipping", "record", fixedRecord, "ip", ip)
+				ctx.notifySynced(fixedRecord)
+				return
+			}
+		}
+		if err := apply(); err != nil {
+			// setRecord already invoked OnError; just log the cron-level context here.
+			log.Error("Error updating record on cron tick", "error", err, "record", fixedRecord)
+		}
 	})
 
 	if err != nil {
-		log.Error("Error scheduling cron job", "error", err, "record", subdomain)
+		log.Error("Error scheduling cron job", "error", err, "record", fixedRecord)
 		return nil, err
 	}
 
 	stop = func() {
 		ctx.cron.Remove(entryID)
-		log.Info("Stopped cron job", "record", subdomain)
+		log.Info("Stopped cron job", "record", fixedRecord)
 	}
 
 	return stop, nil
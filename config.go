@@ -0,0 +1,186 @@
+package cfdyndns
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RecordConfig describes a single DNS record to keep in sync, along with its own
+// schedule.
+type RecordConfig struct {
+	// Subdomain to update or create. Use "@" or an empty string for the zone apex.
+	Subdomain string `yaml:"subdomain"`
+	// Type is the DNS record type, e.g. "A", "AAAA" or "CNAME". Defaults to "A".
+	Type string `yaml:"type"`
+	// Target is the hostname a CNAME record should resolve to. Required when Type is
+	// "CNAME"; ignored otherwise.
+	Target string `yaml:"target"`
+	// Proxied indicates whether the record should be proxied through Cloudflare.
+	Proxied bool `yaml:"proxied"`
+	// Cron is a valid cron expression controlling how often this record is refreshed.
+	// Defaults to "@daily" if empty.
+	Cron string `yaml:"cron"`
+}
+
+// ZoneConfig groups the records managed under a single Cloudflare zone.
+type ZoneConfig struct {
+	// Domain is the main domain name (zone) the records below belong to.
+	Domain string `yaml:"domain"`
+	// Records lists every subdomain managed under Domain.
+	Records []RecordConfig `yaml:"records"`
+}
+
+// ConfigModel is the root of a cfdyndns YAML configuration file, describing one or more
+// zones and the records to keep up to date within each of them.
+type ConfigModel struct {
+	// APIToken is the Cloudflare API token. Falls back to the CF_API_TOKEN environment
+	// variable when empty.
+	APIToken string `yaml:"api_token"`
+	// Zones lists every Cloudflare zone to manage. Falls back to a single zone built from
+	// the CF_DOMAIN and CF_HOSTS environment variables when empty.
+	Zones []ZoneConfig `yaml:"zones"`
+}
+
+// loadConfig reads and parses a ConfigModel from a YAML file, then merges in environment
+// variable fallbacks (CF_API_TOKEN, CF_DOMAIN, CF_HOSTS) for any fields left unset in the
+// file.
+//
+// CF_HOSTS is a comma-separated list of subdomains; each one is added as an unproxied "A"
+// record refreshed "@daily".
+//
+// Parameters:
+//   - path: Path to the YAML configuration file.
+//
+// Returns:
+//   - ConfigModel: The parsed and env-merged configuration.
+//   - error: An error if the file could not be read/parsed, or no API token was found.
+func loadConfig(path string) (ConfigModel, error) {
+	var cfg ConfigModel
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("could not parse config file %q: %w", path, err)
+	}
+
+	if cfg.APIToken == "" {
+		cfg.APIToken = os.Getenv("CF_API_TOKEN")
+	}
+	if cfg.APIToken == "" {
+		return cfg, fmt.Errorf("no Cloudflare API token in %q or CF_API_TOKEN", path)
+	}
+
+	if len(cfg.Zones) == 0 {
+		domain := os.Getenv("CF_DOMAIN")
+		hosts := os.Getenv("CF_HOSTS")
+		if domain == "" || hosts == "" {
+			return cfg, fmt.Errorf("no zones in %q and CF_DOMAIN/CF_HOSTS are not set", path)
+		}
+
+		var records []RecordConfig
+		for _, host := range strings.Split(hosts, ",") {
+			host = strings.TrimSpace(host)
+			if host == "" {
+				continue
+			}
+			records = append(records, RecordConfig{Subdomain: host, Type: "A", Cron: "@daily"})
+		}
+
+		cfg.Zones = []ZoneConfig{{Domain: domain, Records: records}}
+	}
+
+	for zi := range cfg.Zones {
+		for ri := range cfg.Zones[zi].Records {
+			record := &cfg.Zones[zi].Records[ri]
+			if record.Type == "" {
+				record.Type = "A"
+			}
+			if record.Cron == "" {
+				record.Cron = "@daily"
+			}
+			if strings.ToUpper(record.Type) == "CNAME" && record.Target == "" {
+				return cfg, fmt.Errorf("record %q in zone %q: type CNAME requires target", record.Subdomain, cfg.Zones[zi].Domain)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// NewFromConfig creates a new cfdyndns instance from a YAML configuration file describing
+// one or more zones and the records to manage within them. Fields left out of the file
+// fall back to the CF_API_TOKEN, CF_DOMAIN and CF_HOSTS environment variables.
+//
+// Parameters:
+//   - path: Path to the YAML configuration file.
+//
+// Returns:
+//   - *cfdyndns: A pointer to the newly created cfdyndns instance.
+//   - error: An error if any issues occur while loading the config or initializing the client.
+func NewFromConfig(path string) (*cfdyndns, error) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := New(cfg.APIToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.cfg = &cfg
+	return ctx, nil
+}
+
+// RunAll schedules every record described by the configuration passed to NewFromConfig,
+// using each record's own cron expression.
+//
+// Returns:
+//   - error: An error if ctx wasn't created with NewFromConfig, or if any record failed to
+//     schedule. Already-scheduled records are left running.
+func (ctx *cfdyndns) RunAll() error {
+	if ctx.cfg == nil {
+		return fmt.Errorf("RunAll requires a cfdyndns instance created with NewFromConfig")
+	}
+
+	for _, zone := range ctx.cfg.Zones {
+		for _, record := range zone.Records {
+			log.Infof("Scheduling %s.%s (%s)", record.Subdomain, zone.Domain, record.Type)
+			if _, err := ctx.scheduleConfigRecord(zone.Domain, record); err != nil {
+				return fmt.Errorf("could not schedule %s.%s: %w", record.Subdomain, zone.Domain, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// scheduleConfigRecord schedules a single RecordConfig on its own cron expression,
+// dispatching to the typed setter matching record.Type instead of always following the
+// machine's auto-detected address family.
+func (ctx *cfdyndns) scheduleConfigRecord(domain string, record RecordConfig) (stop func(), err error) {
+	switch strings.ToUpper(record.Type) {
+	case "A":
+		v4 := true
+		return ctx.schedule(domain, record.Subdomain, record.Cron, &v4, func() error {
+			return ctx.SetA(domain, record.Subdomain, record.Proxied, 0)
+		})
+	case "AAAA":
+		v4 := false
+		return ctx.schedule(domain, record.Subdomain, record.Cron, &v4, func() error {
+			return ctx.SetAAAA(domain, record.Subdomain, record.Proxied, 0)
+		})
+	case "CNAME":
+		return ctx.schedule(domain, record.Subdomain, record.Cron, nil, func() error {
+			return ctx.SetCNAME(domain, record.Subdomain, record.Target, record.Proxied, 0)
+		})
+	default:
+		return nil, fmt.Errorf("unsupported record type %q for %s", record.Type, record.Subdomain)
+	}
+}
@@ -0,0 +1,157 @@
+package cfdyndns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("could not write temp config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigUsesFileValuesOverEnv(t *testing.T) {
+	t.Setenv("CF_API_TOKEN", "env-token")
+	t.Setenv("CF_DOMAIN", "env.example.com")
+	t.Setenv("CF_HOSTS", "env-host")
+
+	path := writeTempConfig(t, `
+api_token: file-token
+zones:
+  - domain: file.example.com
+    records:
+      - subdomain: www
+`)
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	if cfg.APIToken != "file-token" {
+		t.Errorf("APIToken = %q, want %q", cfg.APIToken, "file-token")
+	}
+	if len(cfg.Zones) != 1 || cfg.Zones[0].Domain != "file.example.com" {
+		t.Errorf("Zones = %+v, want a single file.example.com zone", cfg.Zones)
+	}
+}
+
+func TestLoadConfigFallsBackToAPITokenEnv(t *testing.T) {
+	t.Setenv("CF_API_TOKEN", "env-token")
+
+	path := writeTempConfig(t, `
+zones:
+  - domain: example.com
+    records:
+      - subdomain: www
+`)
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if cfg.APIToken != "env-token" {
+		t.Errorf("APIToken = %q, want %q", cfg.APIToken, "env-token")
+	}
+}
+
+func TestLoadConfigErrorsWithoutAnyAPIToken(t *testing.T) {
+	t.Setenv("CF_API_TOKEN", "")
+
+	path := writeTempConfig(t, `
+zones:
+  - domain: example.com
+    records:
+      - subdomain: www
+`)
+
+	if _, err := loadConfig(path); err == nil {
+		t.Error("expected an error when no API token is available from the file or environment")
+	}
+}
+
+func TestLoadConfigBuildsZoneFromDomainAndHostsEnv(t *testing.T) {
+	t.Setenv("CF_DOMAIN", "env.example.com")
+	t.Setenv("CF_HOSTS", "www, api ,")
+
+	path := writeTempConfig(t, `api_token: file-token`)
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	if len(cfg.Zones) != 1 || cfg.Zones[0].Domain != "env.example.com" {
+		t.Fatalf("Zones = %+v, want a single env.example.com zone", cfg.Zones)
+	}
+
+	records := cfg.Zones[0].Records
+	if len(records) != 2 {
+		t.Fatalf("Records = %+v, want 2 records parsed from CF_HOSTS", records)
+	}
+	if records[0].Subdomain != "www" || records[1].Subdomain != "api" {
+		t.Errorf("Records = %+v, want [www api]", records)
+	}
+	for _, r := range records {
+		if r.Type != "A" {
+			t.Errorf("record %q Type = %q, want %q", r.Subdomain, r.Type, "A")
+		}
+		if r.Cron != "@daily" {
+			t.Errorf("record %q Cron = %q, want %q", r.Subdomain, r.Cron, "@daily")
+		}
+	}
+}
+
+func TestLoadConfigErrorsWithoutZonesOrEnvFallback(t *testing.T) {
+	t.Setenv("CF_DOMAIN", "")
+	t.Setenv("CF_HOSTS", "")
+
+	path := writeTempConfig(t, `api_token: file-token`)
+
+	if _, err := loadConfig(path); err == nil {
+		t.Error("expected an error when no zones are configured and CF_DOMAIN/CF_HOSTS are unset")
+	}
+}
+
+func TestLoadConfigDefaultsRecordTypeAndCron(t *testing.T) {
+	path := writeTempConfig(t, `
+api_token: file-token
+zones:
+  - domain: example.com
+    records:
+      - subdomain: www
+`)
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	record := cfg.Zones[0].Records[0]
+	if record.Type != "A" {
+		t.Errorf("Type = %q, want %q", record.Type, "A")
+	}
+	if record.Cron != "@daily" {
+		t.Errorf("Cron = %q, want %q", record.Cron, "@daily")
+	}
+}
+
+func TestLoadConfigRequiresTargetForCNAME(t *testing.T) {
+	path := writeTempConfig(t, `
+api_token: file-token
+zones:
+  - domain: example.com
+    records:
+      - subdomain: www
+        type: CNAME
+`)
+
+	if _, err := loadConfig(path); err == nil {
+		t.Error("expected an error for a CNAME record without a target")
+	}
+}
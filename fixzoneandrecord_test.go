@@ -0,0 +1,104 @@
+package cfdyndns
+
+import (
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestFixZoneAndRecord(t *testing.T) {
+	domain, subdomain := fixZoneAndRecord("example.com", "www")
+	if domain != "example.com" {
+		t.Errorf("domain = %q, want %q", domain, "example.com")
+	}
+	if subdomain != "www.example.com" {
+		t.Errorf("subdomain = %q, want %q", subdomain, "www.example.com")
+	}
+}
+
+func TestFixZoneAndRecordTrimsWhitespaceAndCase(t *testing.T) {
+	domain, subdomain := fixZoneAndRecord("  Example.COM  ", "  WWW  ")
+	if domain != "example.com" {
+		t.Errorf("domain = %q, want %q", domain, "example.com")
+	}
+	if subdomain != "www.example.com" {
+		t.Errorf("subdomain = %q, want %q", subdomain, "www.example.com")
+	}
+}
+
+func TestFixZoneAndRecordIsNotIdempotent(t *testing.T) {
+	// fixZoneAndRecord strips the zone suffix from subdomain before re-appending it, so
+	// feeding it an already-fixed subdomain mangles the result. Callers (schedule,
+	// setRecord) must each call it at most once per logical update; this pins down the
+	// exact failure mode so a regression reintroducing a double call is caught here
+	// instead of as a silently-wrong DNS record name.
+	domain, subdomain := fixZoneAndRecord("example.com", "www")
+
+	_, doubled := fixZoneAndRecord(domain, subdomain)
+	if doubled == subdomain {
+		t.Fatalf("expected re-fixing %q to change it, got the same value", subdomain)
+	}
+	if doubled != "www..example.com" {
+		t.Errorf("doubled = %q, want %q", doubled, "www..example.com")
+	}
+}
+
+func TestRecordUpToDateMatchesIdenticalRecord(t *testing.T) {
+	proxied := true
+	existing := cloudflare.DNSRecord{Type: "A", Content: "1.2.3.4", TTL: 1, Proxied: &proxied}
+	spec := recordSpec{Type: "A", Content: "1.2.3.4"}
+
+	if !recordUpToDate(existing, spec, 1, true) {
+		t.Error("expected an identical record to be reported up to date")
+	}
+}
+
+func TestRecordUpToDateDetectsProxiedChange(t *testing.T) {
+	proxied := true
+	existing := cloudflare.DNSRecord{Type: "A", Content: "1.2.3.4", TTL: 1, Proxied: &proxied}
+	spec := recordSpec{Type: "A", Content: "1.2.3.4"}
+
+	if recordUpToDate(existing, spec, 1, false) {
+		t.Error("expected a proxied change to be detected")
+	}
+}
+
+func TestRecordUpToDateDetectsPriorityChange(t *testing.T) {
+	proxied := false
+	oldPriority := uint16(10)
+	newPriority := uint16(20)
+	existing := cloudflare.DNSRecord{Type: "MX", Content: "mail.example.com", TTL: 1, Proxied: &proxied, Priority: &oldPriority}
+	spec := recordSpec{Type: "MX", Content: "mail.example.com", Priority: &newPriority}
+
+	if recordUpToDate(existing, spec, 1, false) {
+		t.Error("expected an MX priority change to be detected")
+	}
+}
+
+func TestRecordUpToDateIgnoresEqualPriority(t *testing.T) {
+	proxied := false
+	p := uint16(10)
+	q := uint16(10)
+	existing := cloudflare.DNSRecord{Type: "MX", Content: "mail.example.com", TTL: 1, Proxied: &proxied, Priority: &p}
+	spec := recordSpec{Type: "MX", Content: "mail.example.com", Priority: &q}
+
+	if !recordUpToDate(existing, spec, 1, false) {
+		t.Error("expected equal priorities (different pointers) to compare as up to date")
+	}
+}
+
+func TestRecordUpToDateDetectsDataChange(t *testing.T) {
+	proxied := false
+	existing := cloudflare.DNSRecord{
+		Type: "SRV", TTL: 1, Proxied: &proxied,
+		Data: map[string]interface{}{"port": 5060},
+	}
+	spec := recordSpec{
+		Type: "SRV",
+		Data: map[string]interface{}{"port": 5061},
+	}
+
+	if recordUpToDate(existing, spec, 1, false) {
+		t.Error("expected an SRV data change to be detected")
+	}
+}
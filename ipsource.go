@@ -0,0 +1,362 @@
+package cfdyndns
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jipaix/whatsmyip"
+)
+
+// IPSource detects the machine's current public IP address. New uses it instead of
+// hardcoding a single third-party HTTP echo service, so it can be swapped for whatever
+// is reachable from a given environment (see WithIPSource).
+type IPSource interface {
+	// Detect returns the machine's current public IP address for the requested family:
+	// wantV4 true asks for IPv4, false for IPv6. An implementation that has no way to
+	// resolve the requested family (e.g. a NAT-only STUN server asked for IPv6) must
+	// return an error rather than an address of the wrong family, so New/refreshIP can
+	// tell "not available" apart from a bug in the source.
+	Detect(ctx context.Context, wantV4 bool) (net.IP, error)
+}
+
+// familyLabel renders wantV4 as the "4" or "6" of "IPv4"/"IPv6", for error messages.
+func familyLabel(wantV4 bool) string {
+	if wantV4 {
+		return "4"
+	}
+	return "6"
+}
+
+// whatsmyipSource is the default IPSource, backed by the jipaix/whatsmyip HTTP echo
+// service used by earlier versions of this package. The underlying service has no notion
+// of address family: it returns whatever the outbound connection it saw was made over, so
+// only one of wantV4/!wantV4 can ever succeed against it.
+type whatsmyipSource struct{}
+
+func (whatsmyipSource) Detect(ctx context.Context, wantV4 bool) (net.IP, error) {
+	ip, _, err := whatsmyip.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("could not parse IP %q", ip)
+	}
+
+	if (parsed.To4() != nil) != wantV4 {
+		return nil, fmt.Errorf("whatsmyipSource: no IPv%s address available", familyLabel(wantV4))
+	}
+
+	return parsed, nil
+}
+
+// HTTPIPSource detects the public IP by fetching a plaintext body from each URL in turn,
+// failing over to the next one on error and round-robining which URL is tried first
+// across calls. Each URL's response body must be nothing but the IP address, optionally
+// surrounded by whitespace.
+//
+// URLs is tried for IPv4 detection (e.g. https://api.ipify.org, https://ifconfig.me/ip);
+// URLsV6 is tried for IPv6 detection (e.g. https://api6.ipify.org). A family with no URLs
+// configured simply fails Detect for that family, the same as a source with no IPv6
+// connectivity.
+type HTTPIPSource struct {
+	URLs   []string
+	URLsV6 []string
+	Client *http.Client
+
+	next   int
+	nextV6 int
+}
+
+// Detect implements IPSource.
+func (s *HTTPIPSource) Detect(ctx context.Context, wantV4 bool) (net.IP, error) {
+	urls, next := s.URLs, &s.next
+	if !wantV4 {
+		urls, next = s.URLsV6, &s.nextV6
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("HTTPIPSource: no URLs configured for IPv%s", familyLabel(wantV4))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	var lastErr error
+	for i := 0; i < len(urls); i++ {
+		url := urls[(*next+i)%len(urls)]
+
+		ip, err := fetchIP(ctx, client, url)
+		if err != nil {
+			lastErr = err
+			log.Warn("HTTPIPSource: request failed, trying next URL", "url", url, "error", err)
+			continue
+		}
+		if (ip.To4() != nil) != wantV4 {
+			lastErr = fmt.Errorf("%s returned an IPv%s address", url, familyLabel(!wantV4))
+			log.Warn("HTTPIPSource: unexpected family, trying next URL", "url", url, "error", lastErr)
+			continue
+		}
+
+		*next = (*next + i + 1) % len(urls)
+		return ip, nil
+	}
+
+	return nil, fmt.Errorf("HTTPIPSource: all URLs failed: %w", lastErr)
+}
+
+func fetchIP(ctx context.Context, client *http.Client, url string) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return nil, fmt.Errorf("could not parse IP from response body")
+	}
+
+	return ip, nil
+}
+
+// DNSIPSource detects the public IP by querying a DNS resolver known to echo back the
+// requesting client's address, such as OpenDNS's myip.opendns.com or Google's
+// o-o.myaddr.l.google.com. Resolver defaults to OpenDNS's resolver1.opendns.com:53.
+type DNSIPSource struct {
+	// Resolver is the "host:port" of the DNS server to query.
+	Resolver string
+	// Hostname is the special-purpose name to resolve, e.g. "myip.opendns.com".
+	Hostname string
+	// TXT, when true, resolves Hostname as a TXT record instead of A/AAAA (required for
+	// Google's o-o.myaddr.l.google.com). The family requested by Detect is enforced by
+	// filtering the TXT records' parsed addresses rather than by the query itself.
+	TXT bool
+}
+
+// Detect implements IPSource.
+func (s DNSIPSource) Detect(ctx context.Context, wantV4 bool) (net.IP, error) {
+	resolverAddr := s.Resolver
+	if resolverAddr == "" {
+		resolverAddr = "resolver1.opendns.com:53"
+	}
+	hostname := s.Hostname
+	if hostname == "" {
+		hostname = "myip.opendns.com"
+	}
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 5 * time.Second}
+			return d.DialContext(ctx, network, resolverAddr)
+		},
+	}
+
+	if s.TXT {
+		records, err := resolver.LookupTXT(ctx, hostname)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			ip := net.ParseIP(strings.Trim(r, `"`))
+			if ip != nil && (ip.To4() != nil) == wantV4 {
+				return ip, nil
+			}
+		}
+		return nil, fmt.Errorf("DNSIPSource: no IPv%s address found in TXT records for %s", familyLabel(wantV4), hostname)
+	}
+
+	network := "ip4"
+	if !wantV4 {
+		network = "ip6"
+	}
+
+	ips, err := resolver.LookupIP(ctx, network, hostname)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("DNSIPSource: no IPv%s addresses found for %s", familyLabel(wantV4), hostname)
+	}
+
+	return ips[0], nil
+}
+
+// STUNIPSource detects the public IP behind a NAT by sending a STUN (RFC 5389) binding
+// request to Server and reading the reflexive address back from the response. It only
+// supports IPv4: the mapped-address attributes it decodes carry an explicit address
+// family, and NAT traversal (STUN's usual purpose) is overwhelmingly an IPv4 concern, so
+// Detect rejects a request for IPv6 outright rather than querying Server just to fail.
+type STUNIPSource struct {
+	// Server is the "host:port" of the STUN server to query, e.g. "stun.l.google.com:19302".
+	Server string
+}
+
+const (
+	stunMagicCookie       = 0x2112A442
+	stunBindingRequest    = 0x0001
+	stunBindingSuccess    = 0x0101
+	stunXORMappedAddress  = 0x0020
+	stunMappedAddress     = 0x0001
+	stunAttrFamilyIPv4    = 0x01
+	stunAttrFamilyIPv6    = 0x02
+	stunHeaderLen         = 20
+	stunTransactionIDSize = 12
+)
+
+// Detect implements IPSource.
+func (s STUNIPSource) Detect(ctx context.Context, wantV4 bool) (net.IP, error) {
+	if !wantV4 {
+		return nil, fmt.Errorf("STUNIPSource: IPv6 detection is not supported")
+	}
+	if s.Server == "" {
+		return nil, fmt.Errorf("STUNIPSource: no server configured")
+	}
+
+	conn, err := net.Dial("udp", s.Server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+
+	req := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint16(req[2:4], 0)
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	// Transaction ID doesn't need to be cryptographically random for a single in-flight
+	// request/response pair.
+	copy(req[8:20], []byte("cfdyndnsstun"))
+
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 512)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp = resp[:n]
+
+	if n < stunHeaderLen || binary.BigEndian.Uint16(resp[0:2]) != stunBindingSuccess {
+		return nil, fmt.Errorf("STUNIPSource: unexpected response from %s", s.Server)
+	}
+
+	return parseSTUNMappedAddress(resp[stunHeaderLen:])
+}
+
+func parseSTUNMappedAddress(attrs []byte) (net.IP, error) {
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if len(attrs) < 4+attrLen {
+			break
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunXORMappedAddress:
+			if ip := decodeXORMappedAddress(value); ip != nil {
+				return ip, nil
+			}
+		case stunMappedAddress:
+			if ip := decodeMappedAddress(value); ip != nil {
+				return ip, nil
+			}
+		}
+
+		// Attributes are padded to a multiple of 4 bytes.
+		advance := 4 + attrLen + (4-attrLen%4)%4
+		if advance > len(attrs) {
+			break
+		}
+		attrs = attrs[advance:]
+	}
+
+	return nil, fmt.Errorf("STUNIPSource: no mapped address attribute in response")
+}
+
+func decodeMappedAddress(value []byte) net.IP {
+	if len(value) < 8 || value[1] != stunAttrFamilyIPv4 {
+		return nil
+	}
+	return net.IP(value[4:8])
+}
+
+func decodeXORMappedAddress(value []byte) net.IP {
+	if len(value) < 8 || value[1] != stunAttrFamilyIPv4 {
+		return nil
+	}
+	xored := make([]byte, 4)
+	magic := make([]byte, 4)
+	binary.BigEndian.PutUint32(magic, stunMagicCookie)
+	for i := 0; i < 4; i++ {
+		xored[i] = value[4+i] ^ magic[i]
+	}
+	return net.IP(xored)
+}
+
+// InterfaceIPSource reads the public IP directly off a local network interface, for
+// machines where the WAN address is configured on-box rather than behind NAT.
+type InterfaceIPSource struct {
+	// Name is the network interface to read, e.g. "eth0".
+	Name string
+}
+
+// Detect implements IPSource.
+func (s InterfaceIPSource) Detect(ctx context.Context, wantV4 bool) (net.IP, error) {
+	iface, err := net.InterfaceByName(s.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if (ipnet.IP.To4() != nil) != wantV4 {
+			continue
+		}
+		if ipnet.IP.IsGlobalUnicast() && !ipnet.IP.IsPrivate() {
+			return ipnet.IP, nil
+		}
+	}
+
+	return nil, fmt.Errorf("InterfaceIPSource: no public IPv%s address found on %s", familyLabel(wantV4), s.Name)
+}
@@ -0,0 +1,131 @@
+package cfdyndns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// buildMappedAddressAttr builds a raw MAPPED-ADDRESS (or XOR-MAPPED-ADDRESS, same layout
+// before XOR'ing) attribute value for an IPv4 address/port pair.
+func buildMappedAddressAttr(ip net.IP, port uint16) []byte {
+	v := make([]byte, 8)
+	v[1] = stunAttrFamilyIPv4
+	binary.BigEndian.PutUint16(v[2:4], port)
+	copy(v[4:8], ip.To4())
+	return v
+}
+
+func buildXORMappedAddressAttr(ip net.IP, port uint16) []byte {
+	v := buildMappedAddressAttr(ip, port)
+
+	magic := make([]byte, 4)
+	binary.BigEndian.PutUint32(magic, stunMagicCookie)
+
+	portXor := binary.BigEndian.Uint16(v[2:4]) ^ uint16(stunMagicCookie>>16)
+	binary.BigEndian.PutUint16(v[2:4], portXor)
+	for i := 0; i < 4; i++ {
+		v[4+i] ^= magic[i]
+	}
+	return v
+}
+
+// withAttrHeader wraps value in a STUN attribute header and pads it to a 4-byte boundary,
+// matching how a compliant STUN server encodes attributes on the wire.
+func withAttrHeader(attrType uint16, value []byte) []byte {
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint16(header[0:2], attrType)
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(value)))
+
+	padded := append([]byte{}, value...)
+	for len(padded)%4 != 0 {
+		padded = append(padded, 0)
+	}
+	return append(header, padded...)
+}
+
+func TestDecodeMappedAddress(t *testing.T) {
+	want := net.ParseIP("203.0.113.42").To4()
+	got := decodeMappedAddress(buildMappedAddressAttr(want, 12345))
+	if got == nil || !got.Equal(want) {
+		t.Errorf("decodeMappedAddress() = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeMappedAddressRejectsIPv6Family(t *testing.T) {
+	v := buildMappedAddressAttr(net.ParseIP("203.0.113.42"), 1)
+	v[1] = stunAttrFamilyIPv6
+	if got := decodeMappedAddress(v); got != nil {
+		t.Errorf("decodeMappedAddress() = %v, want nil for an IPv6-family attribute", got)
+	}
+}
+
+func TestDecodeMappedAddressRejectsShortValue(t *testing.T) {
+	if got := decodeMappedAddress([]byte{0, 1, 0, 0}); got != nil {
+		t.Errorf("decodeMappedAddress() = %v, want nil for a truncated attribute", got)
+	}
+}
+
+func TestDecodeXORMappedAddress(t *testing.T) {
+	want := net.ParseIP("203.0.113.42").To4()
+	got := decodeXORMappedAddress(buildXORMappedAddressAttr(want, 12345))
+	if got == nil || !got.Equal(want) {
+		t.Errorf("decodeXORMappedAddress() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSTUNMappedAddressReturnsFirstRecognizedAttribute(t *testing.T) {
+	want := net.ParseIP("198.51.100.7").To4()
+	other := net.ParseIP("203.0.113.42").To4()
+
+	var attrs []byte
+	attrs = append(attrs, withAttrHeader(stunXORMappedAddress, buildXORMappedAddressAttr(want, 1))...)
+	attrs = append(attrs, withAttrHeader(stunMappedAddress, buildMappedAddressAttr(other, 1))...)
+
+	got, err := parseSTUNMappedAddress(attrs)
+	if err != nil {
+		t.Fatalf("parseSTUNMappedAddress() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("parseSTUNMappedAddress() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSTUNMappedAddressFallsBackToMappedAddress(t *testing.T) {
+	want := net.ParseIP("198.51.100.7").To4()
+
+	attrs := withAttrHeader(stunMappedAddress, buildMappedAddressAttr(want, 1))
+
+	got, err := parseSTUNMappedAddress(attrs)
+	if err != nil {
+		t.Fatalf("parseSTUNMappedAddress() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("parseSTUNMappedAddress() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSTUNMappedAddressSkipsUnknownPaddedAttributes(t *testing.T) {
+	want := net.ParseIP("198.51.100.7").To4()
+
+	var attrs []byte
+	// A 3-byte unknown attribute, padded to 4 bytes, must not desync parsing of the
+	// attribute that follows it.
+	attrs = append(attrs, withAttrHeader(0x9999, []byte{0x01, 0x02, 0x03})...)
+	attrs = append(attrs, withAttrHeader(stunMappedAddress, buildMappedAddressAttr(want, 1))...)
+
+	got, err := parseSTUNMappedAddress(attrs)
+	if err != nil {
+		t.Fatalf("parseSTUNMappedAddress() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("parseSTUNMappedAddress() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSTUNMappedAddressErrorsWithNoAddressAttribute(t *testing.T) {
+	attrs := withAttrHeader(0x9999, []byte{0x01, 0x02})
+	if _, err := parseSTUNMappedAddress(attrs); err == nil {
+		t.Error("expected an error when no mapped-address attribute is present")
+	}
+}
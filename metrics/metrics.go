@@ -0,0 +1,227 @@
+// Package metrics provides an optional Prometheus metrics and health-check subsystem for
+// cfdyndns, so it can be run as a first-class citizen in Kubernetes and other
+// observability-driven deployments.
+//
+// It has no dependency on the cfdyndns package itself; wiring happens from the other
+// direction via cfdyndns.WithMetrics and (*cfdyndns).ServeMetrics.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry collects every metric cfdyndns exposes, and backs the /healthz and /readyz
+// endpoints served by ServeMetrics.
+type Registry struct {
+	reg *prometheus.Registry
+
+	updatesTotal  prometheus.Counter
+	failuresTotal *prometheus.CounterVec
+	currentIP     *prometheus.GaugeVec
+	apiLatency    *prometheus.HistogramVec
+
+	// Freshness is how long ago a record must have last synced successfully to be
+	// considered healthy. Defaults to 24h.
+	Freshness time.Duration
+
+	mu       sync.Mutex
+	tracked  map[string]struct{}
+	lastSync map[string]time.Time
+}
+
+// NewRegistry creates a Registry with all collectors registered against a fresh
+// Prometheus registry.
+func NewRegistry() *Registry {
+	reg := prometheus.NewRegistry()
+
+	r := &Registry{
+		reg:       reg,
+		Freshness: 24 * time.Hour,
+		tracked:   make(map[string]struct{}),
+		lastSync:  make(map[string]time.Time),
+		updatesTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cfdyndns",
+			Name:      "updates_total",
+			Help:      "Number of successful DNS record updates.",
+		}),
+		failuresTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cfdyndns",
+			Name:      "failures_total",
+			Help:      "Number of failed DNS record updates, labeled by error class.",
+		}, []string{"class"}),
+		currentIP: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "cfdyndns",
+			Name:      "current_ip",
+			Help:      "Currently tracked IP address, as a gauge with the IP as a label.",
+		}, []string{"record", "ip"}),
+		apiLatency: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "cfdyndns",
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency in seconds of Cloudflare API requests and IP-detection lookups, labeled by op.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+	}
+
+	reg.MustRegister(syncAgeCollector{r})
+
+	return r
+}
+
+// syncAgeCollector exposes Registry.secondsSinceLastSync as a live-computed
+// cfdyndns_seconds_since_last_sync gauge, labeled by record.
+type syncAgeCollector struct {
+	r *Registry
+}
+
+var syncAgeDesc = prometheus.NewDesc(
+	"cfdyndns_seconds_since_last_sync",
+	"Seconds since the last successful sync of a tracked record. -1 if never synced.",
+	[]string{"record"}, nil,
+)
+
+func (c syncAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- syncAgeDesc
+}
+
+func (c syncAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	for record, age := range c.r.secondsSinceLastSync() {
+		ch <- prometheus.MustNewConstMetric(syncAgeDesc, prometheus.GaugeValue, age, record)
+	}
+}
+
+// RegisterRecord marks record as one cfdyndns is expected to keep in sync, so /healthz
+// can report it as unhealthy until its first successful sync.
+func (r *Registry) RegisterRecord(record string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracked[record] = struct{}{}
+}
+
+// RecordSuccess records a successful create/update of record to ip.
+func (r *Registry) RecordSuccess(record, ip string) {
+	r.updatesTotal.Inc()
+	r.currentIP.DeletePartialMatch(prometheus.Labels{"record": record})
+	r.currentIP.WithLabelValues(record, ip).Set(1)
+	r.RecordSynced(record)
+}
+
+// RecordSynced marks record as freshly reconciled without it having actually changed at
+// Cloudflare — either setRecord found the existing record already matched the desired
+// state, or a cron tick's IP re-check found nothing to do and skipped setRecord entirely.
+// Both are a successful sync as far as /healthz's freshness window is concerned: without
+// this, a DDNS record that simply never needs to change would still trip "stale" after
+// Freshness even though it's perfectly correct. It doesn't touch updatesTotal or
+// current_ip, since nothing was actually updated.
+func (r *Registry) RecordSynced(record string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracked[record] = struct{}{}
+	r.lastSync[record] = time.Now()
+}
+
+// RecordFailure records a failed update, classified by class (e.g. "cloudflare",
+// "ip-detection").
+func (r *Registry) RecordFailure(class string) {
+	r.failuresTotal.WithLabelValues(class).Inc()
+}
+
+// ObserveAPILatency records how long a single attempt of the call labeled op took. cfdyndns
+// reports both Cloudflare API calls and IP-detection lookups through it.
+func (r *Registry) ObserveAPILatency(op string, d time.Duration) {
+	r.apiLatency.WithLabelValues(op).Observe(d.Seconds())
+}
+
+// secondsSinceLastSync reports, per tracked record, how long it's been since the last
+// successful sync. Unsynced records report -1.
+func (r *Registry) secondsSinceLastSync() map[string]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string]float64, len(r.tracked))
+	for record := range r.tracked {
+		last, ok := r.lastSync[record]
+		if !ok {
+			out[record] = -1
+			continue
+		}
+		out[record] = time.Since(last).Seconds()
+	}
+	return out
+}
+
+// healthy reports whether every tracked record has synced within Freshness.
+func (r *Registry) healthy() bool {
+	for _, age := range r.secondsSinceLastSync() {
+		if age < 0 || time.Duration(age*float64(time.Second)) > r.Freshness {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeMetrics starts an HTTP server on addr exposing:
+//   - /metrics: Prometheus metrics in the standard text exposition format.
+//   - /healthz: 200 if every registered record synced within Freshness, 503 otherwise.
+//   - /readyz: 200 once at least one record has synced successfully; 503 before that.
+//
+// Returns:
+//   - stop: A function that shuts the server down.
+//   - error: An error if the server failed to start listening.
+func (r *Registry) ServeMetrics(addr string) (stop func(), err error) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(r.reg, promhttp.HandlerOpts{}))
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, req *http.Request) {
+		if r.healthy() {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "ok")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "stale")
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		ready := len(r.lastSync) > 0
+		r.mu.Unlock()
+
+		if ready {
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "ready")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "not ready")
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Println("metrics: server error:", err)
+		}
+	}()
+
+	stop = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}
+
+	return stop, nil
+}
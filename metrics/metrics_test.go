@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestHealthyBeforeAnyRecordIsRegistered(t *testing.T) {
+	r := NewRegistry()
+
+	if !r.healthy() {
+		t.Error("expected a registry with no tracked records to report healthy")
+	}
+}
+
+func TestUnhealthyUntilFirstSync(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterRecord("www.example.com")
+
+	if r.healthy() {
+		t.Error("expected a registered but never-synced record to report unhealthy")
+	}
+
+	ages := r.secondsSinceLastSync()
+	if got := ages["www.example.com"]; got != -1 {
+		t.Errorf("secondsSinceLastSync() = %v, want -1 for a never-synced record", got)
+	}
+}
+
+func TestHealthyAfterRecordSuccess(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterRecord("www.example.com")
+	r.RecordSuccess("www.example.com", "1.2.3.4")
+
+	if !r.healthy() {
+		t.Error("expected a freshly-synced record to report healthy")
+	}
+}
+
+func TestUnhealthyOnceSyncIsStale(t *testing.T) {
+	r := NewRegistry()
+	r.Freshness = time.Millisecond
+	r.RegisterRecord("www.example.com")
+	r.RecordSuccess("www.example.com", "1.2.3.4")
+
+	time.Sleep(5 * time.Millisecond)
+
+	if r.healthy() {
+		t.Error("expected a record synced longer ago than Freshness to report unhealthy")
+	}
+}
+
+func TestRecordSuccessAlsoRegistersUnseenRecords(t *testing.T) {
+	r := NewRegistry()
+	r.RecordSuccess("www.example.com", "1.2.3.4")
+
+	if !r.healthy() {
+		t.Error("expected RecordSuccess to implicitly track the record it just synced")
+	}
+}
+
+func TestRecordSyncedCountsAsHealthyWithoutAnUpdate(t *testing.T) {
+	// A record whose content never changes (the DDNS steady state) should stay healthy
+	// forever, not just until the first real create/update's Freshness window expires.
+	r := NewRegistry()
+	r.Freshness = time.Millisecond
+	r.RegisterRecord("www.example.com")
+	r.RecordSuccess("www.example.com", "1.2.3.4")
+
+	time.Sleep(5 * time.Millisecond)
+	r.RecordSynced("www.example.com")
+
+	if !r.healthy() {
+		t.Error("expected RecordSynced to refresh the freshness window without a real update")
+	}
+}
+
+func TestRecordSyncedDoesNotCountAsAnUpdate(t *testing.T) {
+	r := NewRegistry()
+	r.RecordSynced("www.example.com")
+
+	metric := &dto.Metric{}
+	if err := r.updatesTotal.Write(metric); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := metric.GetCounter().GetValue(); got != 0 {
+		t.Errorf("updates_total = %v after RecordSynced, want 0 (no record was actually created/updated)", got)
+	}
+}
+
+func TestRecordSuccessPreservesOtherRecordsCurrentIP(t *testing.T) {
+	r := NewRegistry()
+	r.RecordSuccess("a.example.com", "1.1.1.1")
+	r.RecordSuccess("b.example.com", "2.2.2.2")
+
+	metric := &dto.Metric{}
+	if err := r.currentIP.WithLabelValues("a.example.com", "1.1.1.1").Write(metric); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if metric.GetGauge().GetValue() != 1 {
+		t.Errorf("a.example.com's current_ip series = %v after b.example.com synced, want it to survive untouched", metric.GetGauge().GetValue())
+	}
+}
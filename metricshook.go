@@ -0,0 +1,33 @@
+package cfdyndns
+
+import (
+	"errors"
+
+	"github.com/JiPaix/cfdyndns/metrics"
+)
+
+var errMetricsNotEnabled = errors.New("metrics not enabled: pass WithMetrics to New")
+
+// WithMetrics enables the Prometheus metrics and health-check subsystem, recording every
+// update/failure into reg. Pair with (*cfdyndns).ServeMetrics to expose it over HTTP.
+func WithMetrics(reg *metrics.Registry) Option {
+	return func(ctx *cfdyndns) {
+		ctx.metrics = reg
+	}
+}
+
+// ServeMetrics starts serving /metrics, /healthz and /readyz on addr using the Registry
+// passed to WithMetrics.
+//
+// Parameters:
+//   - addr: The "host:port" to listen on.
+//
+// Returns:
+//   - stop: A function that shuts the metrics server down.
+//   - error: An error if metrics weren't enabled via WithMetrics, or the server failed to start.
+func (ctx *cfdyndns) ServeMetrics(addr string) (stop func(), err error) {
+	if ctx.metrics == nil {
+		return nil, errMetricsNotEnabled
+	}
+	return ctx.metrics.ServeMetrics(addr)
+}
@@ -0,0 +1,100 @@
+package cfdyndns
+
+import "time"
+
+// ChangeEvent describes a single successful DNS record create/update, passed to OnChange
+// callbacks.
+type ChangeEvent struct {
+	Zone      string
+	Record    string
+	Type      string
+	OldIP     string
+	NewIP     string
+	Proxied   bool
+	Timestamp time.Time
+}
+
+// ChangeFunc is called after every successful record create/update.
+type ChangeFunc func(event ChangeEvent)
+
+// ErrorFunc is called whenever a Cloudflare call, or the IP re-detection run on a
+// scheduled tick, fails once its retries are exhausted, including inside an Auto cron
+// tick where such failures would otherwise go unnoticed until the next scheduled run.
+// New's initial IP detection does not report through it; New only logs those failures.
+type ErrorFunc func(err error)
+
+// Notifier bundles a ChangeFunc and an ErrorFunc, letting a single adapter (webhook,
+// Discord/Slack, SMTP, ...) handle both. See WithNotifier.
+type Notifier interface {
+	OnChange(event ChangeEvent)
+	OnError(err error)
+}
+
+// WithOnChange registers fn to be called after every successful record create/update.
+func WithOnChange(fn ChangeFunc) Option {
+	return func(ctx *cfdyndns) {
+		ctx.onChange = fn
+	}
+}
+
+// WithOnError registers fn to be called whenever a Cloudflare call or a scheduled IP
+// re-detection fails (see ErrorFunc).
+func WithOnError(fn ErrorFunc) Option {
+	return func(ctx *cfdyndns) {
+		ctx.onError = fn
+	}
+}
+
+// WithNotifier wires both the OnChange and OnError callbacks from a single Notifier
+// implementation, such as one of the built-in adapters in the notify subpackage.
+func WithNotifier(n Notifier) Option {
+	return func(ctx *cfdyndns) {
+		ctx.onChange = n.OnChange
+		ctx.onError = n.OnError
+	}
+}
+
+func (ctx *cfdyndns) notifyChange(zone, record, recordType, oldIP, newIP string, proxied bool) {
+	if ctx.metrics != nil {
+		ctx.metrics.RecordSuccess(record, newIP)
+	}
+
+	if ctx.onChange == nil {
+		return
+	}
+	ctx.onChange(ChangeEvent{
+		Zone:      zone,
+		Record:    record,
+		Type:      recordType,
+		OldIP:     oldIP,
+		NewIP:     newIP,
+		Proxied:   proxied,
+		Timestamp: time.Now(),
+	})
+}
+
+// notifySynced marks record as freshly reconciled without an actual Cloudflare write —
+// setRecord's "already up to date" branch and schedule's "IP unchanged" cron-tick skip
+// both count as a successful sync for /healthz's freshness window. There's no
+// corresponding OnChange callback: nothing changed, so there's nothing to tell a caller
+// about.
+func (ctx *cfdyndns) notifySynced(record string) {
+	if ctx.metrics != nil {
+		ctx.metrics.RecordSynced(record)
+	}
+}
+
+func (ctx *cfdyndns) notifyError(err error, class string) {
+	if err == nil {
+		return
+	}
+
+	if ctx.metrics != nil {
+		ctx.metrics.RecordFailure(class)
+	}
+
+	if ctx.onError == nil {
+		return
+	}
+	ctx.onError(err)
+}
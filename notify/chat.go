@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JiPaix/cfdyndns"
+)
+
+// Chat posts human-readable messages to a Discord or Slack incoming webhook. Both
+// services accept the same {"content"|"text": "..."} shaped payload well enough that a
+// single adapter covers either, selected via Kind.
+type Chat struct {
+	URL  string
+	Kind ChatKind
+}
+
+// ChatKind selects the JSON field name expected by the target webhook.
+type ChatKind int
+
+const (
+	// Discord posts using Discord's {"content": "..."} webhook payload.
+	Discord ChatKind = iota
+	// Slack posts using Slack's {"text": "..."} incoming webhook payload.
+	Slack
+)
+
+func (c Chat) send(message string) error {
+	field := "content"
+	if c.Kind == Slack {
+		field = "text"
+	}
+
+	return Webhook{URL: c.URL}.post(map[string]string{field: message})
+}
+
+// OnChange implements cfdyndns.Notifier.
+func (c Chat) OnChange(event cfdyndns.ChangeEvent) {
+	message := fmt.Sprintf(":white_check_mark: `%s` (%s) on `%s` now points to `%s` (was `%s`), proxied=%t",
+		event.Record, event.Type, event.Zone, event.NewIP, event.OldIP, event.Proxied)
+	if err := c.send(message); err != nil {
+		fmt.Fprintln(os.Stderr, "notify: chat delivery failed:", err)
+	}
+}
+
+// OnError implements cfdyndns.Notifier.
+func (c Chat) OnError(err error) {
+	message := fmt.Sprintf(":x: cfdyndns error: %s", err)
+	if sendErr := c.send(message); sendErr != nil {
+		fmt.Fprintln(os.Stderr, "notify: chat delivery failed:", sendErr)
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+
+	"github.com/JiPaix/cfdyndns"
+)
+
+// SMTP emails a plaintext notification for every record change or error through a
+// standard SMTP server.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (s SMTP) send(subject, body string) error {
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
+	auth := smtp.PlainAuth("", s.Username, s.Password, s.Host)
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		s.From, joinAddrs(s.To), subject, body)
+
+	return smtp.SendMail(addr, auth, s.From, s.To, []byte(msg))
+}
+
+func joinAddrs(addrs []string) string {
+	joined := ""
+	for i, a := range addrs {
+		if i > 0 {
+			joined += ", "
+		}
+		joined += a
+	}
+	return joined
+}
+
+// OnChange implements cfdyndns.Notifier.
+func (s SMTP) OnChange(event cfdyndns.ChangeEvent) {
+	subject := fmt.Sprintf("[cfdyndns] %s updated", event.Record)
+	body := fmt.Sprintf("%s (%s) on %s now points to %s (was %s), proxied=%t, at %s",
+		event.Record, event.Type, event.Zone, event.NewIP, event.OldIP, event.Proxied, event.Timestamp)
+
+	if err := s.send(subject, body); err != nil {
+		fmt.Fprintln(os.Stderr, "notify: smtp delivery failed:", err)
+	}
+}
+
+// OnError implements cfdyndns.Notifier.
+func (s SMTP) OnError(err error) {
+	if sendErr := s.send("[cfdyndns] error", err.Error()); sendErr != nil {
+		fmt.Fprintln(os.Stderr, "notify: smtp delivery failed:", sendErr)
+	}
+}
@@ -0,0 +1,67 @@
+// Package notify provides built-in cfdyndns.Notifier adapters for common outbound
+// channels: a generic JSON webhook, Discord/Slack incoming webhooks, and SMTP email.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/JiPaix/cfdyndns"
+)
+
+// Webhook POSTs a JSON-encoded ChangeEvent to URL after every successful record update,
+// and a small JSON error payload to URL on failures.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// webhookErrorPayload is the body posted to URL by OnError.
+type webhookErrorPayload struct {
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (w Webhook) client() *http.Client {
+	if w.Client != nil {
+		return w.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (w Webhook) post(body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client().Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// OnChange implements cfdyndns.Notifier.
+func (w Webhook) OnChange(event cfdyndns.ChangeEvent) {
+	if err := w.post(event); err != nil {
+		fmt.Fprintln(os.Stderr, "notify: webhook delivery failed:", err)
+	}
+}
+
+// OnError implements cfdyndns.Notifier.
+func (w Webhook) OnError(err error) {
+	if postErr := w.post(webhookErrorPayload{Error: err.Error(), Timestamp: time.Now()}); postErr != nil {
+		fmt.Fprintln(os.Stderr, "notify: webhook delivery failed:", postErr)
+	}
+}
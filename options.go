@@ -0,0 +1,15 @@
+package cfdyndns
+
+// Option customizes a cfdyndns instance at construction time. Options are applied, in
+// order, before New performs its initial IP detection.
+type Option func(*cfdyndns)
+
+// WithIPSource overrides the default IP detection backend (the jipaix/whatsmyip HTTP
+// echo service) with src. Useful in environments where the default service is blocked or
+// unreliable; see HTTPIPSource, DNSIPSource, STUNIPSource and InterfaceIPSource for
+// built-in alternatives.
+func WithIPSource(src IPSource) Option {
+	return func(ctx *cfdyndns) {
+		ctx.ipSource = src
+	}
+}
@@ -0,0 +1,161 @@
+package cfdyndns
+
+import "fmt"
+
+// recordSpec describes the type-specific fields of a single DNS record, independent of
+// the zone/subdomain/proxied routing handled by setRecord.
+type recordSpec struct {
+	Type     string
+	Content  string
+	TTL      int // 0 means "use Cloudflare's automatic TTL".
+	Priority *uint16
+	Data     interface{}
+}
+
+// SRVRecord holds the service-specific fields of an SRV record, on top of the Proxied and
+// TTL options shared with every other record type.
+type SRVRecord struct {
+	// Service is the symbolic service name, e.g. "sip" (without the leading underscore).
+	Service string
+	// Proto is the transport protocol, e.g. "tcp" (without the leading underscore).
+	Proto string
+	// Priority controls which target is tried first; lower values are preferred.
+	Priority uint16
+	// Weight is used to choose between targets of the same Priority.
+	Weight uint16
+	// Port is the TCP/UDP port the service runs on.
+	Port uint16
+	// Target is the hostname serving the service.
+	Target string
+}
+
+// SetA upserts an A record pointing subdomain at the machine's cached public IPv4
+// address.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain to update or create. Use "@" or an empty string for the zone apex.
+//   - proxied: A boolean indicating whether the record should be proxied through Cloudflare.
+//   - ttl: Time-to-live in seconds, or 0 to use Cloudflare's automatic TTL.
+//
+// Returns:
+//   - error: An error if any issues occur during the update process.
+func (ctx *cfdyndns) SetA(domain string, subdomain string, proxied bool, ttl int) error {
+	ctx.ipMu.Lock()
+	ip4 := ctx.ip4
+	ctx.ipMu.Unlock()
+
+	if ip4 == "" {
+		return fmt.Errorf("no IPv4 address available")
+	}
+	return ctx.setRecord(domain, subdomain, recordSpec{Type: "A", Content: ip4, TTL: ttl}, proxied)
+}
+
+// SetAAAA upserts an AAAA record pointing subdomain at the machine's cached public IPv6
+// address.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain to update or create. Use "@" or an empty string for the zone apex.
+//   - proxied: A boolean indicating whether the record should be proxied through Cloudflare.
+//   - ttl: Time-to-live in seconds, or 0 to use Cloudflare's automatic TTL.
+//
+// Returns:
+//   - error: An error if any issues occur during the update process.
+func (ctx *cfdyndns) SetAAAA(domain string, subdomain string, proxied bool, ttl int) error {
+	ctx.ipMu.Lock()
+	ip6 := ctx.ip6
+	ctx.ipMu.Unlock()
+
+	if ip6 == "" {
+		return fmt.Errorf("no IPv6 address available")
+	}
+	return ctx.setRecord(domain, subdomain, recordSpec{Type: "AAAA", Content: ip6, TTL: ttl}, proxied)
+}
+
+// SetCNAME upserts a CNAME record pointing subdomain at target.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain to update or create. Use "@" or an empty string for the zone apex.
+//   - target: The hostname the CNAME record should resolve to.
+//   - proxied: A boolean indicating whether the record should be proxied through Cloudflare.
+//   - ttl: Time-to-live in seconds, or 0 to use Cloudflare's automatic TTL.
+//
+// Returns:
+//   - error: An error if any issues occur during the update process.
+func (ctx *cfdyndns) SetCNAME(domain string, subdomain string, target string, proxied bool, ttl int) error {
+	return ctx.setRecord(domain, subdomain, recordSpec{Type: "CNAME", Content: target, TTL: ttl}, proxied)
+}
+
+// SetTXT upserts a TXT record with the given value. TXT records can't be proxied through
+// Cloudflare.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain to update or create. Use "@" or an empty string for the zone apex.
+//   - value: The text content of the record.
+//   - ttl: Time-to-live in seconds, or 0 to use Cloudflare's automatic TTL.
+//
+// Returns:
+//   - error: An error if any issues occur during the update process.
+func (ctx *cfdyndns) SetTXT(domain string, subdomain string, value string, ttl int) error {
+	return ctx.setRecord(domain, subdomain, recordSpec{Type: "TXT", Content: value, TTL: ttl}, false)
+}
+
+// SetMX upserts an MX record pointing subdomain at target with the given priority. MX
+// records can't be proxied through Cloudflare.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain to update or create. Use "@" or an empty string for the zone apex.
+//   - target: The mail server hostname.
+//   - priority: The record's preference value; lower values are tried first.
+//   - ttl: Time-to-live in seconds, or 0 to use Cloudflare's automatic TTL.
+//
+// Returns:
+//   - error: An error if any issues occur during the update process.
+func (ctx *cfdyndns) SetMX(domain string, subdomain string, target string, priority uint16, ttl int) error {
+	p := priority
+	return ctx.setRecord(domain, subdomain, recordSpec{Type: "MX", Content: target, Priority: &p, TTL: ttl}, false)
+}
+
+// SetSRV upserts an SRV record for the given service/protocol. SRV records can't be
+// proxied through Cloudflare.
+//
+// Parameters:
+//   - domain: The main domain name (zone) to update.
+//   - subdomain: The subdomain the service is advertised under. Use "@" or an empty string for the zone apex.
+//   - srv: The service's priority, weight, port and target.
+//   - ttl: Time-to-live in seconds, or 0 to use Cloudflare's automatic TTL.
+//
+// Returns:
+//   - error: An error if any issues occur during the update process.
+func (ctx *cfdyndns) SetSRV(domain string, subdomain string, srv SRVRecord, ttl int) error {
+	return ctx.setRecord(domain, srvName(srv, subdomain), srvRecordSpec(srv, ttl), false)
+}
+
+// srvName builds the record name setRecord should upsert for srv: the service and
+// protocol labels prefixed onto subdomain, e.g. srvName({Service: "sip", Proto: "tcp"},
+// "www") = "_sip._tcp.www". setRecord runs this through fixZoneAndRecord exactly like
+// any other record, so it ends up fully qualified (e.g. "_sip._tcp.www.example.com").
+func srvName(srv SRVRecord, subdomain string) string {
+	return fmt.Sprintf("_%s._%s.%s", srv.Service, srv.Proto, subdomain)
+}
+
+// srvRecordSpec builds the recordSpec for an SRV record. Data only carries the fields
+// that aren't already expressed by the record's Name (built by srvName): repeating
+// service/proto/name inside Data as well produced a doubly-qualified name at Cloudflare,
+// since the two representations disagree about where the prefix lives.
+func srvRecordSpec(srv SRVRecord, ttl int) recordSpec {
+	return recordSpec{
+		Type: "SRV",
+		TTL:  ttl,
+		Data: map[string]interface{}{
+			"priority": srv.Priority,
+			"weight":   srv.Weight,
+			"port":     srv.Port,
+			"target":   srv.Target,
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package cfdyndns
+
+import "testing"
+
+func TestSRVNameQualifiesServiceAndProto(t *testing.T) {
+	srv := SRVRecord{Service: "sip", Proto: "tcp"}
+
+	got := srvName(srv, "www")
+	want := "_sip._tcp.www"
+	if got != want {
+		t.Errorf("srvName() = %q, want %q", got, want)
+	}
+}
+
+func TestSRVRecordSpecOmitsNameFieldsAlreadyInName(t *testing.T) {
+	// Data must not repeat service/proto/name: those are already expressed by the Name
+	// srvName builds, and sending both produced a doubly-qualified record at Cloudflare.
+	srv := SRVRecord{Service: "sip", Proto: "tcp", Priority: 10, Weight: 60, Port: 5060, Target: "bigbox.example.com"}
+
+	spec := srvRecordSpec(srv, 300)
+
+	if spec.Type != "SRV" {
+		t.Errorf("Type = %q, want %q", spec.Type, "SRV")
+	}
+	if spec.TTL != 300 {
+		t.Errorf("TTL = %d, want %d", spec.TTL, 300)
+	}
+
+	data, ok := spec.Data.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Data = %T, want map[string]interface{}", spec.Data)
+	}
+	for _, key := range []string{"service", "proto", "name"} {
+		if _, present := data[key]; present {
+			t.Errorf("Data[%q] = %v, want it absent (already carried by Name)", key, data[key])
+		}
+	}
+
+	want := map[string]interface{}{
+		"priority": srv.Priority,
+		"weight":   srv.Weight,
+		"port":     srv.Port,
+		"target":   srv.Target,
+	}
+	for key, wantValue := range want {
+		if got := data[key]; got != wantValue {
+			t.Errorf("Data[%q] = %v, want %v", key, got, wantValue)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cfdyndns
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+// familyIPSource returns v4 for an IPv4 request and v6 for an IPv6 request, regardless of
+// which one the caller asked for last time, letting tests simulate each family moving
+// independently.
+type familyIPSource struct {
+	v4, v6 string
+}
+
+func (s familyIPSource) Detect(ctx context.Context, wantV4 bool) (net.IP, error) {
+	if wantV4 {
+		return net.ParseIP(s.v4), nil
+	}
+	return net.ParseIP(s.v6), nil
+}
+
+func TestRefreshIPTracksEachFamilyIndependently(t *testing.T) {
+	ctx := &cfdyndns{
+		ipSource: familyIPSource{v4: "1.1.1.1", v6: "2001:db8::1"},
+		retry:    DefaultRetryPolicy(),
+		ip4:      "1.1.1.1",
+		ip6:      "2001:db8::1",
+	}
+
+	// The IPv4 address is unchanged; only IPv6 moved. An AAAA record's tick must detect
+	// that, independent of A's address being unchanged.
+	ctx.ipSource = familyIPSource{v4: "1.1.1.1", v6: "2001:db8::2"}
+
+	if changed, ip, err := ctx.refreshIP(true); err != nil || changed || ip != "1.1.1.1" {
+		t.Errorf("refreshIP(true) = (%v, %q, %v), want (false, \"1.1.1.1\", nil)", changed, ip, err)
+	}
+
+	changed, ip, err := ctx.refreshIP(false)
+	if err != nil {
+		t.Fatalf("refreshIP(false): %v", err)
+	}
+	if !changed || ip != "2001:db8::2" {
+		t.Errorf("refreshIP(false) = (%v, %q), want (true, \"2001:db8::2\")", changed, ip)
+	}
+	if ctx.ip6 != "2001:db8::2" {
+		t.Errorf("ctx.ip6 = %q, want %q", ctx.ip6, "2001:db8::2")
+	}
+	if ctx.ip4 != "1.1.1.1" {
+		t.Errorf("ctx.ip4 = %q, want it untouched by refreshIP(false)", ctx.ip4)
+	}
+}
+
+func TestRefreshIPSyncsLegacySingleStackFieldForItsOwnFamily(t *testing.T) {
+	ctx := &cfdyndns{
+		ipSource: familyIPSource{v4: "1.1.1.1", v6: "2001:db8::1"},
+		retry:    DefaultRetryPolicy(),
+		ip:       "1.1.1.1",
+		ip4:      "1.1.1.1",
+		v4:       true,
+	}
+
+	ctx.ipSource = familyIPSource{v4: "9.9.9.9", v6: "2001:db8::1"}
+
+	if _, _, err := ctx.refreshIP(true); err != nil {
+		t.Fatalf("refreshIP(true): %v", err)
+	}
+	if ctx.ip != "9.9.9.9" {
+		t.Errorf("ctx.ip = %q, want %q (Set follows ctx.v4's family)", ctx.ip, "9.9.9.9")
+	}
+
+	// An AAAA tick refreshing the other family must not touch the legacy single-stack
+	// ctx.ip, since ctx.v4 still points at IPv4.
+	if _, _, err := ctx.refreshIP(false); err != nil {
+		t.Fatalf("refreshIP(false): %v", err)
+	}
+	if ctx.ip != "9.9.9.9" {
+		t.Errorf("ctx.ip = %q after an AAAA-family refresh, want it unchanged at %q", ctx.ip, "9.9.9.9")
+	}
+}
@@ -0,0 +1,123 @@
+package cfdyndns
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// RetryPolicy controls how setRecord and IP detection retry transient failures against
+// Cloudflare and the configured IPSource.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one. 1 disables
+	// retrying entirely.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles after every subsequent
+	// failed attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff.
+	MaxDelay time.Duration
+	// Jitter is the fraction (0..1) of the computed delay to randomize, to avoid
+	// thundering-herd retries across many cfdyndns instances.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the retry settings used by New when WithRetryPolicy isn't
+// passed: 5 attempts, starting at 500ms and doubling up to 30s, with 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+}
+
+// WithRetryPolicy overrides the default retry behavior around Cloudflare API calls and
+// IP detection.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(ctx *cfdyndns) {
+		ctx.retry = policy
+	}
+}
+
+// withRetry runs fn, retrying according to policy when fn returns a retryable error.
+// Non-retryable errors and context cancellation return immediately.
+func (ctx *cfdyndns) withRetry(parent context.Context, op string, fn func() error) error {
+	policy := ctx.retry
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	delay := policy.BaseDelay
+	var err error
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		start := time.Now()
+		err = fn()
+		if ctx.metrics != nil {
+			ctx.metrics.ObserveAPILatency(op, time.Since(start))
+		}
+		if err == nil {
+			return nil
+		}
+
+		if attempt == policy.MaxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := delay
+		if policy.Jitter > 0 {
+			wait += time.Duration(rand.Float64() * policy.Jitter * float64(wait))
+		}
+
+		log.Warn("Retrying after error", "op", op, "attempt", attempt, "wait", wait, "error", err)
+
+		select {
+		case <-time.After(wait):
+		case <-parent.Done():
+			return parent.Err()
+		}
+
+		delay *= 2
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+
+	return err
+}
+
+// isRetryable reports whether err looks like a transient failure worth retrying: a
+// Cloudflare rate limit / server error, or a network-level error.
+//
+// Every error cloudflare-go returns for an API call (RatelimitError, ServiceError,
+// RequestError, ...) wraps a *cloudflare.Error exposing StatusCode and
+// ClientRateLimited(), so a 429/5xx is detected precisely via errors.As instead of
+// matching on the error message, which both missed real Cloudflare errors with unexpected
+// wording and false-positived on any unrelated message that happened to contain "503".
+//
+// cloudflare-go doesn't expose the response's Retry-After header on *cloudflare.Error
+// though (its own internal request loop reads it before an error ever reaches us), so
+// even a rate limit that specifies its own Retry-After still backs off on policy's
+// exponential delay here.
+func isRetryable(err error) bool {
+	var cfErr *cloudflare.Error
+	if errors.As(err, &cfErr) {
+		return cfErr.ClientRateLimited() || cfErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"timeout", "connection reset", "temporary failure"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
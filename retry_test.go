@@ -0,0 +1,118 @@
+package cfdyndns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func TestIsRetryable(t *testing.T) {
+	rateLimited := cloudflare.NewRatelimitError(&cloudflare.Error{StatusCode: http.StatusTooManyRequests, Type: cloudflare.ErrorTypeRateLimit})
+	serviceUnavailable := cloudflare.NewServiceError(&cloudflare.Error{StatusCode: http.StatusServiceUnavailable})
+	internalServerError := cloudflare.NewServiceError(&cloudflare.Error{StatusCode: http.StatusInternalServerError})
+	notFound := cloudflare.NewNotFoundError(&cloudflare.Error{StatusCode: http.StatusNotFound})
+	badRequest := cloudflare.NewRequestError(&cloudflare.Error{StatusCode: http.StatusBadRequest})
+
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("dial tcp: i/o timeout"), true},
+		{errors.New("read: connection reset by peer"), true},
+		{rateLimited, true},
+		{serviceUnavailable, true},
+		{internalServerError, true},
+		{notFound, false},
+		{badRequest, false},
+		{errors.New("record not found"), false},
+		{errors.New("invalid zone identifier"), false},
+		// A plain error whose message merely mentions a status code must not be mistaken
+		// for a real Cloudflare response; only a genuine *cloudflare.Error counts.
+		{errors.New("zone lookup failed: saw 503 in a log line"), false},
+	}
+
+	for _, c := range cases {
+		if got := isRetryable(c.err); got != c.want {
+			t.Errorf("isRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestWithRetryStopsAfterMaxAttempts(t *testing.T) {
+	ctx := &cfdyndns{retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}
+
+	attempts := 0
+	err := ctx.withRetry(context.Background(), "test op", func() error {
+		attempts++
+		return errors.New("timeout")
+	})
+
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+	if err == nil {
+		t.Error("expected the last attempt's error to be returned")
+	}
+}
+
+func TestWithRetryStopsEarlyOnNonRetryableError(t *testing.T) {
+	ctx := &cfdyndns{retry: RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}
+
+	attempts := 0
+	nonRetryable := errors.New("invalid zone identifier")
+	err := ctx.withRetry(context.Background(), "test op", func() error {
+		attempts++
+		return nonRetryable
+	})
+
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 for a non-retryable error", attempts)
+	}
+	if !errors.Is(err, nonRetryable) {
+		t.Errorf("err = %v, want %v", err, nonRetryable)
+	}
+}
+
+func TestWithRetrySucceedsWithoutExhaustingAttempts(t *testing.T) {
+	ctx := &cfdyndns{retry: RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}}
+
+	attempts := 0
+	err := ctx.withRetry(context.Background(), "test op", func() error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("timeout")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestWithRetryHonorsContextCancellation(t *testing.T) {
+	ctx := &cfdyndns{retry: RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Second}}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := ctx.withRetry(cancelCtx, "test op", func() error {
+		attempts++
+		return errors.New("timeout")
+	})
+
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 before the cancellation is observed", attempts)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}